@@ -0,0 +1,318 @@
+package main
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+
+	"github.com/andygrunwald/go-jira"
+	"github.com/charmbracelet/huh"
+	"github.com/dghubble/oauth1"
+	"github.com/pkg/browser"
+)
+
+// AuthCreds holds the credentials needed to authenticate against Jira, for
+// either the basic or oauth AuthMethod.
+type AuthCreds struct {
+	AuthMethod string
+
+	// Basic auth
+	Username string
+	Token    string
+
+	// OAuth 1.0a
+	ConsumerKey    string
+	PrivateKeyPath string
+	AccessToken    string
+	AccessSecret   string
+
+	BaseURL string
+
+	// SavedQueries maps a short name (invoked as "@name") to a JQL string,
+	// letting users bookmark release-planning queries instead of retyping
+	// them.
+	SavedQueries map[string]string `json:",omitempty"`
+}
+
+var baseURL string
+
+var ErrIncompleteCredentials = errors.New("must provide 'username' and 'token' keys in file")
+
+// oauthEndpoint returns the three-legged OAuth 1.0a endpoint for a Jira
+// server rooted at baseURL, per the plugins/servlet/oauth servlet.
+func oauthEndpoint(baseURL string) oauth1.Endpoint {
+	return oauth1.Endpoint{
+		RequestTokenURL: baseURL + "plugins/servlet/oauth/request-token",
+		AuthorizeURL:    baseURL + "plugins/servlet/oauth/authorize",
+		AccessTokenURL:  baseURL + "plugins/servlet/oauth/access-token",
+	}
+}
+
+// parseRSAKey reads and parses a PEM-encoded RSA private key from path,
+// accepting either PKCS#1 or PKCS#8 encoding.
+func parseRSAKey(path string) (*rsa.PrivateKey, error) {
+	data, err := os.ReadFile(os.ExpandEnv(path))
+	if err != nil {
+		return nil, fmt.Errorf("reading private key: %w", err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data found in %s", path)
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parsing private key: %w", err)
+	}
+
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("parsing private key: not an RSA key")
+	}
+
+	return rsaKey, nil
+}
+
+// doOAuthDance runs the three-legged OAuth 1.0a authorization flow against
+// creds.BaseURL, prompting the user via huh to authorize the app in their
+// browser and enter the resulting verifier code. On success it fills in
+// creds.AccessToken and creds.AccessSecret.
+func doOAuthDance(creds *AuthCreds) error {
+	key, err := parseRSAKey(creds.PrivateKeyPath)
+	if err != nil {
+		return err
+	}
+
+	config := &oauth1.Config{
+		ConsumerKey: creds.ConsumerKey,
+		CallbackURL: "oob",
+		Endpoint:    oauthEndpoint(creds.BaseURL),
+		Signer:      &oauth1.RSASigner{PrivateKey: key},
+	}
+
+	requestToken, requestSecret, err := config.RequestToken()
+	if err != nil {
+		return fmt.Errorf("getting OAuth request token: %w", err)
+	}
+
+	authURL, err := config.AuthorizationURL(requestToken)
+	if err != nil {
+		return fmt.Errorf("building authorization URL: %w", err)
+	}
+
+	if err := browser.OpenURL(authURL.String()); err != nil {
+		fmt.Printf("Open this URL in a browser to authorize jiradeps: %s\n", authURL.String())
+	}
+
+	var verifier string
+	err = huh.NewInput().
+		Title("Verification Code").
+		Description(fmt.Sprintf("Authorize jiradeps at %s, then paste the verification code here", authURL.String())).
+		Value(&verifier).
+		Validate(func(s string) error {
+			if s == "" {
+				return fmt.Errorf("Verification code cannot be empty")
+			}
+			return nil
+		}).Run()
+	if err != nil {
+		return fmt.Errorf("prompting for verification code: %w", err)
+	}
+
+	accessToken, accessSecret, err := config.AccessToken(requestToken, requestSecret, verifier)
+	if err != nil {
+		return fmt.Errorf("exchanging verifier for access token: %w", err)
+	}
+
+	creds.AccessToken = accessToken
+	creds.AccessSecret = accessSecret
+
+	return nil
+}
+
+// jiraClientForCreds builds an authenticated *jira.Client for creds,
+// selecting a basic-auth or OAuth1-signing transport based on AuthMethod.
+func jiraClientForCreds(creds AuthCreds) (*jira.Client, error) {
+	switch creds.AuthMethod {
+	case "oauth":
+		key, err := parseRSAKey(creds.PrivateKeyPath)
+		if err != nil {
+			return nil, err
+		}
+
+		config := &oauth1.Config{
+			ConsumerKey: creds.ConsumerKey,
+			Endpoint:    oauthEndpoint(creds.BaseURL),
+			Signer:      &oauth1.RSASigner{PrivateKey: key},
+		}
+		token := oauth1.NewToken(creds.AccessToken, creds.AccessSecret)
+
+		return jira.NewClient(config.Client(context.Background(), token), creds.BaseURL)
+	default:
+		tp := jira.BasicAuthTransport{
+			Username: creds.Username,
+			Password: creds.Token,
+		}
+
+		return jira.NewClient(tp.Client(), creds.BaseURL)
+	}
+}
+
+func getAuthCreds() (creds AuthCreds, err error) {
+	fileName := os.ExpandEnv("${HOME}/.config/jiradeps.json")
+	var newCreds bool
+	file, err := os.ReadFile(fileName)
+
+	authMethodInput := huh.NewSelect[string]().
+		Title("Authentication Method").
+		Options(
+			huh.NewOption("API Token (basic auth)", "basic"),
+			huh.NewOption("OAuth 1.0a", "oauth"),
+		).
+		Value(&creds.AuthMethod)
+
+	usernameInput := huh.NewInput().
+		Title("Username").
+		Value(&creds.Username).
+		Validate(func(s string) error {
+			if s == "" {
+				return fmt.Errorf("Username cannot be empty")
+			}
+			return nil
+		})
+
+	tokenInput := huh.NewInput().
+		Title("API Token").
+		Value(&creds.Token).
+		Validate(func(s string) error {
+			if s == "" {
+				return fmt.Errorf("API token cannot be empty")
+			}
+			if len(s) < 190 {
+				return fmt.Errorf("API token must be at least 190 characters")
+			}
+			if len(s) > 200 {
+				return fmt.Errorf("API token must be at most 200 characters")
+			}
+			if strings.Contains(s, "\"") {
+				return fmt.Errorf("API token must not contain quotes")
+			}
+			return nil
+		})
+
+	consumerKeyInput := huh.NewInput().
+		Title("OAuth Consumer Key").
+		Value(&creds.ConsumerKey).
+		Validate(func(s string) error {
+			if s == "" {
+				return fmt.Errorf("Consumer Key cannot be empty")
+			}
+			return nil
+		})
+
+	privateKeyInput := huh.NewInput().
+		Title("Path to RSA Private Key").
+		Value(&creds.PrivateKeyPath).
+		Validate(func(s string) error {
+			if s == "" {
+				return fmt.Errorf("Private Key path cannot be empty")
+			}
+			if _, err := os.Stat(os.ExpandEnv(s)); err != nil {
+				return fmt.Errorf("Private Key file not found: %w", err)
+			}
+			return nil
+		})
+
+	baseUrlInput := huh.NewInput().
+		Title("Base URL").
+		Value(&creds.BaseURL).
+		Validate(func(s string) error {
+			if s == "" {
+				return fmt.Errorf("Base URL cannot be empty")
+			}
+			if !strings.HasSuffix(s, "/") {
+				return fmt.Errorf("Base URL must end with a slash")
+			}
+			if !strings.HasPrefix(s, "https://") {
+				return fmt.Errorf("Base URL must start with 'https://'")
+			}
+			return nil
+		})
+
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			// If the file does not exist, prompt for credentials
+			huh.NewForm(huh.NewGroup(baseUrlInput, authMethodInput).Title("Jira Credentials")).Run()
+			newCreds = true
+		} else {
+			return creds, fmt.Errorf("reading file: %w", err)
+		}
+	}
+
+	if !newCreds {
+		if err = json.Unmarshal(file, &creds); err != nil {
+			return creds, fmt.Errorf("unmarshalling file: %w", err)
+		}
+	}
+
+	if creds.AuthMethod == "" {
+		creds.AuthMethod = "basic"
+	}
+
+	if creds.BaseURL == "" {
+		baseUrlInput.Run()
+		newCreds = true
+	}
+
+	switch creds.AuthMethod {
+	case "oauth":
+		if creds.ConsumerKey == "" {
+			consumerKeyInput.Run()
+			newCreds = true
+		}
+		if creds.PrivateKeyPath == "" {
+			privateKeyInput.Run()
+			newCreds = true
+		}
+		if creds.AccessToken == "" || creds.AccessSecret == "" {
+			if err := doOAuthDance(&creds); err != nil {
+				return creds, fmt.Errorf("authorizing via OAuth: %w", err)
+			}
+			newCreds = true
+		}
+	default:
+		if creds.Username == "" {
+			usernameInput.Run()
+			newCreds = true
+		}
+		if creds.Token == "" {
+			tokenInput.Run()
+			newCreds = true
+		}
+	}
+	baseURL = creds.BaseURL
+
+	if newCreds {
+		jsonData, err := json.MarshalIndent(creds, "", "  ")
+		if err != nil {
+			return creds, fmt.Errorf("marshalling credentials: %w", err)
+		}
+		if err = os.WriteFile(fileName, jsonData, 0o600); err != nil {
+			return creds, fmt.Errorf("writing file: %w", err)
+		}
+	}
+
+	return creds, nil
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writePEM(t *testing.T, blockType string, der []byte) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "key.pem")
+	data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("writing test key: %v", err)
+	}
+
+	return path
+}
+
+func TestParseRSAKey(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 512)
+	if err != nil {
+		t.Fatalf("generating RSA key: %v", err)
+	}
+
+	pkcs8Key, err := x509.MarshalPKCS8PrivateKey(rsaKey)
+	if err != nil {
+		t.Fatalf("marshalling PKCS8 key: %v", err)
+	}
+
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generating ed25519 key: %v", err)
+	}
+
+	edDER, err := x509.MarshalPKCS8PrivateKey(edKey)
+	if err != nil {
+		t.Fatalf("marshalling ed25519 key: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		path      string
+		wantErr   bool
+		wantKeyEq *rsa.PrivateKey
+	}{
+		{
+			name:      "PKCS1",
+			path:      writePEM(t, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey)),
+			wantKeyEq: rsaKey,
+		},
+		{
+			name:      "PKCS8",
+			path:      writePEM(t, "PRIVATE KEY", pkcs8Key),
+			wantKeyEq: rsaKey,
+		},
+		{
+			name:    "non-RSA key rejected",
+			path:    writePEM(t, "PRIVATE KEY", edDER),
+			wantErr: true,
+		},
+		{
+			name:    "missing file",
+			path:    filepath.Join(t.TempDir(), "nope.pem"),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseRSAKey(tt.path)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseRSAKey(%q) = nil error, want an error", tt.path)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseRSAKey(%q): %v", tt.path, err)
+			}
+			if !got.Equal(tt.wantKeyEq) {
+				t.Errorf("parseRSAKey(%q) returned a different key than was written", tt.path)
+			}
+		})
+	}
+}
+
+func TestJiraClientForCredsDispatchesOnAuthMethod(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 512)
+	if err != nil {
+		t.Fatalf("generating RSA key: %v", err)
+	}
+	keyPath := writePEM(t, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey))
+
+	tests := []struct {
+		name  string
+		creds AuthCreds
+	}{
+		{
+			name: "basic",
+			creds: AuthCreds{
+				AuthMethod: "basic",
+				Username:   "alice",
+				Token:      "t0ken",
+				BaseURL:    "https://example.atlassian.net/",
+			},
+		},
+		{
+			name: "oauth",
+			creds: AuthCreds{
+				AuthMethod:     "oauth",
+				ConsumerKey:    "consumer-key",
+				PrivateKeyPath: keyPath,
+				AccessToken:    "access-token",
+				AccessSecret:   "access-secret",
+				BaseURL:        "https://example.atlassian.net/",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := jiraClientForCreds(tt.creds)
+			if err != nil {
+				t.Fatalf("jiraClientForCreds(%+v): %v", tt.creds, err)
+			}
+
+			gotURL := client.GetBaseURL()
+			if gotURL.String() != tt.creds.BaseURL {
+				t.Errorf("client base URL = %q, want %q", gotURL.String(), tt.creds.BaseURL)
+			}
+		})
+	}
+}
+
+func TestJiraClientForCredsOAuthBadKeyPath(t *testing.T) {
+	creds := AuthCreds{
+		AuthMethod:     "oauth",
+		ConsumerKey:    "consumer-key",
+		PrivateKeyPath: filepath.Join(t.TempDir(), "nope.pem"),
+		BaseURL:        "https://example.atlassian.net/",
+	}
+
+	if _, err := jiraClientForCreds(creds); err == nil {
+		t.Fatal("jiraClientForCreds with a missing private key = nil error, want an error")
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/andygrunwald/go-jira"
+)
+
+// DefaultCacheTTL is how long a cached issue is considered fresh before it
+// is re-fetched from Jira.
+const DefaultCacheTTL = 15 * time.Minute
+
+// Cache stores fetched Jira issues on disk so that repeated flowchart runs
+// over the same epic don't re-walk the whole REST API.
+type Cache interface {
+	Get(key string) (*jira.Issue, bool)
+	Put(issue *jira.Issue)
+	Invalidate(key string)
+}
+
+// FileCache is a Cache backed by one JSON file per issue under
+// ~/.cache/jiradeps/<baseURL-hash>/<KEY>.json. In offline mode entries never
+// expire; otherwise entries older than TTL are treated as misses.
+type FileCache struct {
+	dir     string
+	ttl     time.Duration
+	offline bool
+}
+
+// NewFileCache returns a FileCache rooted under the user's cache directory,
+// namespaced by baseURL so that multiple Jira instances don't collide.
+func NewFileCache(baseURL string, ttl time.Duration, offline bool) (*FileCache, error) {
+	cacheHome, err := os.UserCacheDir()
+	if err != nil {
+		return nil, fmt.Errorf("finding cache directory: %w", err)
+	}
+
+	sum := sha256.Sum256([]byte(baseURL))
+	dir := filepath.Join(cacheHome, "jiradeps", hex.EncodeToString(sum[:])[:16])
+
+	return newFileCacheAt(dir, ttl, offline)
+}
+
+// newFileCacheAt returns a FileCache rooted directly at dir, skipping the
+// baseURL-derived path under the user's cache directory. Split out of
+// NewFileCache so tests can root a cache under t.TempDir() instead of
+// touching the real ~/.cache/jiradeps.
+func newFileCacheAt(dir string, ttl time.Duration, offline bool) (*FileCache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("creating cache directory: %w", err)
+	}
+
+	return &FileCache{dir: dir, ttl: ttl, offline: offline}, nil
+}
+
+func (c *FileCache) path(key string) string {
+	return filepath.Join(c.dir, key+".json")
+}
+
+func (c *FileCache) Get(key string) (*jira.Issue, bool) {
+	path := c.path(key)
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, false
+	}
+
+	if !c.offline && time.Since(info.ModTime()) > c.ttl {
+		return nil, false
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+
+	issue := new(jira.Issue)
+	if err := json.Unmarshal(data, issue); err != nil {
+		return nil, false
+	}
+
+	return issue, true
+}
+
+func (c *FileCache) Put(issue *jira.Issue) {
+	data, err := json.Marshal(issue)
+	if err != nil {
+		return
+	}
+
+	_ = os.WriteFile(c.path(issue.Key), data, 0o644)
+}
+
+func (c *FileCache) Invalidate(key string) {
+	if err := os.Remove(c.path(key)); err != nil && !os.IsNotExist(err) {
+		fmt.Printf("Warning: could not invalidate cache for %s: %v\n", key, err)
+	}
+}
+
+// getIssueCached fetches issue key via client, consulting cache first and
+// populating it on a live fetch. In offline mode a cache miss is an error
+// rather than falling through to the network.
+func getIssueCached(client *jira.Client, cache Cache, offline bool, key string) (*jira.Issue, error) {
+	if issue, ok := cache.Get(key); ok {
+		return issue, nil
+	}
+
+	if offline {
+		return nil, fmt.Errorf("offline mode: %s not in cache", key)
+	}
+
+	issue, _, err := client.Issue.Get(key, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	cache.Put(issue)
+
+	return issue, nil
+}
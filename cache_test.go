@@ -0,0 +1,43 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/andygrunwald/go-jira"
+)
+
+func TestFileCachePutGetRoundTrip(t *testing.T) {
+	cache, err := newFileCacheAt(t.TempDir(), DefaultCacheTTL, false)
+	if err != nil {
+		t.Fatalf("newFileCacheAt: %v", err)
+	}
+	defer cache.Invalidate("ABC-123")
+
+	issue := &jira.Issue{ID: "10042", Key: "ABC-123"}
+	cache.Put(issue)
+
+	got, ok := cache.Get("ABC-123")
+	if !ok {
+		t.Fatalf("Get(%q): miss after Put", issue.Key)
+	}
+	if got.Key != issue.Key {
+		t.Errorf("Get(%q).Key = %q, want %q", issue.Key, got.Key, issue.Key)
+	}
+
+	// Put stores under issue.Key, so looking it up by the numeric ID (the
+	// bug this test guards against) must miss.
+	if _, ok := cache.Get(issue.ID); ok {
+		t.Errorf("Get(%q) hit; cache must be keyed by Key, not ID", issue.ID)
+	}
+}
+
+func TestFileCacheGetMiss(t *testing.T) {
+	cache, err := newFileCacheAt(t.TempDir(), DefaultCacheTTL, false)
+	if err != nil {
+		t.Fatalf("newFileCacheAt: %v", err)
+	}
+
+	if _, ok := cache.Get("NOPE-1"); ok {
+		t.Errorf("Get(%q) hit on an empty cache", "NOPE-1")
+	}
+}
@@ -0,0 +1,445 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Heiko-san/mermaidgen/flowchart"
+)
+
+// DependencyAnalysis holds the cycles and critical path found by
+// AnalyzeDependencies.
+type DependencyAnalysis struct {
+	// Cycles lists each strongly-connected group of size > 1 (or
+	// single-node self-loop) as a closed chain of keys walked along real
+	// "Blocks" edges, repeating the starting key at the end, e.g.
+	// ["ABC-1", "ABC-4", "ABC-1"].
+	Cycles [][]string
+
+	// CriticalPath is the longest "blocks" chain in the acyclic
+	// condensation that ends at target, ordered from source to target.
+	// Holds only target if no blocking chain leads into it, and is empty if
+	// target has no "Blocks" edge at all.
+	CriticalPath []string
+
+	// sccOf maps each node with a "Blocks" edge to the id of the SCC it
+	// belongs to, so HighlightAnalysis can tell a real cycle edge (same
+	// SCC on both ends) from an edge that merely touches two separate
+	// cycles.
+	sccOf map[string]int
+}
+
+// AnalyzeDependencies runs Tarjan's SCC algorithm over graph's "Blocks"
+// edges (ignoring "Relates" and other link types) to find dependency
+// cycles, then computes the critical path: the longest path in the acyclic
+// condensation that ends at target. If target is "", critical path
+// computation is skipped.
+func AnalyzeDependencies(graph *DepGraph, target string) DependencyAnalysis {
+	adj := blockingAdjacency(graph)
+	sccs := tarjanSCC(adj)
+
+	nodeSCC := make(map[string]int, len(sccs))
+
+	analysis := DependencyAnalysis{sccOf: nodeSCC}
+
+	for id, scc := range sccs {
+		for _, key := range scc {
+			nodeSCC[key] = id
+		}
+
+		if len(scc) > 1 || hasSelfLoop(adj, scc[0]) {
+			analysis.Cycles = append(analysis.Cycles, cycleOrder(adj, scc))
+		}
+	}
+
+	if target != "" {
+		analysis.CriticalPath = criticalPathTo(adj, nodeSCC, sccs, target)
+	}
+
+	return analysis
+}
+
+// blockingAdjacency builds a From->To adjacency list from graph's edges
+// whose type is "Blocks", the only link type that expresses a real
+// dependency ordering; "Relates" and everything else is ignored.
+func blockingAdjacency(graph *DepGraph) map[string][]string {
+	adj := map[string][]string{}
+
+	for _, e := range graph.Edges {
+		if !strings.EqualFold(e.Type, "Blocks") {
+			continue
+		}
+
+		adj[e.From] = append(adj[e.From], e.To)
+	}
+
+	return adj
+}
+
+func hasSelfLoop(adj map[string][]string, key string) bool {
+	for _, to := range adj[key] {
+		if to == key {
+			return true
+		}
+	}
+
+	return false
+}
+
+// hamiltonianCycleSearchLimit bounds the SCC size cycleOrder will attempt a
+// full backtracking search for, above which it falls back to the cheaper
+// (but possibly partial) shortestCycleFrom.
+const hamiltonianCycleSearchLimit = 12
+
+// cycleOrder walks scc's nodes along real edges in adj, returning a closed
+// chain (the starting key repeated at the end) suitable for display, e.g.
+// ["ABC-1", "ABC-4", "ABC-1"]. It prefers a cycle visiting every member of
+// scc, falling back to the shortest cycle back to the start node when a
+// full traversal isn't found (or scc is too large to search exhaustively).
+func cycleOrder(adj map[string][]string, scc []string) []string {
+	if len(scc) == 1 {
+		return []string{scc[0], scc[0]}
+	}
+
+	inSCC := make(map[string]bool, len(scc))
+	for _, key := range scc {
+		inSCC[key] = true
+	}
+
+	sorted := append([]string(nil), scc...)
+	sort.Strings(sorted)
+	start := sorted[0]
+
+	if len(scc) <= hamiltonianCycleSearchLimit {
+		if path := fullCycleFrom(adj, inSCC, start, len(scc)); path != nil {
+			return path
+		}
+	}
+
+	return shortestCycleFrom(adj, inSCC, start)
+}
+
+// fullCycleFrom backtracks from start looking for a cycle that visits all
+// size members of inSCC before closing back to start. Returns nil if no
+// such cycle exists.
+func fullCycleFrom(adj map[string][]string, inSCC map[string]bool, start string, size int) []string {
+	visited := map[string]bool{start: true}
+	path := []string{start}
+
+	var walk func(v string) bool
+	walk = func(v string) bool {
+		neighbors := append([]string(nil), adj[v]...)
+		sort.Strings(neighbors)
+
+		for _, w := range neighbors {
+			if !inSCC[w] {
+				continue
+			}
+
+			if w == start && len(path) == size {
+				path = append(path, start)
+				return true
+			}
+
+			if !visited[w] {
+				visited[w] = true
+				path = append(path, w)
+
+				if walk(w) {
+					return true
+				}
+
+				path = path[:len(path)-1]
+				visited[w] = false
+			}
+		}
+
+		return false
+	}
+
+	if walk(start) {
+		return path
+	}
+
+	return nil
+}
+
+// shortestCycleFrom does a breadth-first search for the shortest cycle
+// (in edges) that leaves start and returns to it via inSCC's edges. It may
+// not visit every member of inSCC, but it's always a real, closed cycle.
+func shortestCycleFrom(adj map[string][]string, inSCC map[string]bool, start string) []string {
+	type item struct {
+		node string
+		path []string
+	}
+
+	visited := map[string]bool{start: true}
+	queue := []item{{start, []string{start}}}
+
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+
+		neighbors := append([]string(nil), adj[cur.node]...)
+		sort.Strings(neighbors)
+
+		for _, w := range neighbors {
+			if !inSCC[w] {
+				continue
+			}
+
+			if w == start {
+				if len(cur.path) > 1 {
+					return append(append([]string(nil), cur.path...), start)
+				}
+
+				continue
+			}
+
+			if !visited[w] {
+				visited[w] = true
+				queue = append(queue, item{w, append(append([]string(nil), cur.path...), w)})
+			}
+		}
+	}
+
+	// Unreachable for a genuine SCC of size > 1: every member has a path
+	// back to every other member, including start.
+	return []string{start, start}
+}
+
+// tarjanSCC computes the strongly connected components of adj using
+// Tarjan's algorithm.
+func tarjanSCC(adj map[string][]string) [][]string {
+	nodes := map[string]bool{}
+	for from, tos := range adj {
+		nodes[from] = true
+		for _, to := range tos {
+			nodes[to] = true
+		}
+	}
+
+	sortedNodes := make([]string, 0, len(nodes))
+	for n := range nodes {
+		sortedNodes = append(sortedNodes, n)
+	}
+
+	sort.Strings(sortedNodes)
+
+	var (
+		index   int
+		indices = map[string]int{}
+		lowlink = map[string]int{}
+		onStack = map[string]bool{}
+		stack   []string
+		sccs    [][]string
+		connect func(v string)
+	)
+
+	connect = func(v string) {
+		indices[v] = index
+		lowlink[v] = index
+		index++
+		stack = append(stack, v)
+		onStack[v] = true
+
+		for _, w := range adj[v] {
+			if _, visited := indices[w]; !visited {
+				connect(w)
+				if lowlink[w] < lowlink[v] {
+					lowlink[v] = lowlink[w]
+				}
+			} else if onStack[w] && indices[w] < lowlink[v] {
+				lowlink[v] = indices[w]
+			}
+		}
+
+		if lowlink[v] == indices[v] {
+			var scc []string
+			for {
+				w := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[w] = false
+				scc = append(scc, w)
+
+				if w == v {
+					break
+				}
+			}
+			sccs = append(sccs, scc)
+		}
+	}
+
+	for _, v := range sortedNodes {
+		if _, visited := indices[v]; !visited {
+			connect(v)
+		}
+	}
+
+	return sccs
+}
+
+// criticalPathTo computes the longest path, in number of edges, through the
+// acyclic condensation of adj (contracted by nodeSCC) that ends at target,
+// then expands it back to a representative key per component, with target
+// itself standing for its own component.
+func criticalPathTo(adj map[string][]string, nodeSCC map[string]int, sccs [][]string, target string) []string {
+	targetSCC, ok := nodeSCC[target]
+	if !ok {
+		return nil
+	}
+
+	condPreds := map[int][]int{}
+	condSeen := map[[2]int]bool{}
+
+	for from, tos := range adj {
+		fromSCC := nodeSCC[from]
+		for _, to := range tos {
+			toSCC := nodeSCC[to]
+			if fromSCC == toSCC {
+				continue // intra-cycle edge, not part of the acyclic condensation
+			}
+
+			key := [2]int{fromSCC, toSCC}
+			if !condSeen[key] {
+				condSeen[key] = true
+				condPreds[toSCC] = append(condPreds[toSCC], fromSCC)
+			}
+		}
+	}
+
+	dist := map[int]int{}
+	parent := map[int]int{}
+
+	var longest func(scc int) int
+	longest = func(scc int) int {
+		if d, ok := dist[scc]; ok {
+			return d
+		}
+
+		best, bestParent := 0, -1
+		for _, pred := range condPreds[scc] {
+			if d := longest(pred) + 1; d > best {
+				best, bestParent = d, pred
+			}
+		}
+
+		dist[scc] = best
+		parent[scc] = bestParent
+
+		return best
+	}
+
+	longest(targetSCC)
+
+	var sccPath []int
+	for scc := targetSCC; scc != -1; scc = parent[scc] {
+		sccPath = append([]int{scc}, sccPath...)
+	}
+
+	keyPath := make([]string, len(sccPath))
+	for i, scc := range sccPath {
+		keyPath[i] = sccs[scc][0]
+	}
+	keyPath[len(keyPath)-1] = target
+
+	return keyPath
+}
+
+var (
+	cycleFill    flowchart.NodeStyle = flowchart.NodeStyle{Fill: `#FF4136`, Stroke: flowchart.ColorBlack}
+	criticalEdge                     = flowchart.EdgeStyle{Stroke: `#FF851B`, StrokeWidth: 4}
+)
+
+// HighlightAnalysis restyles fc's nodes/edges in place to reflect analysis:
+// cycle members turn red with a warning icon, and critical path edges are
+// thickened and colored distinctly. graph is consulted to tell "Blocks"
+// edges apart from "Relates" and other link types, since flowchart.Edge
+// itself doesn't carry a link type.
+func HighlightAnalysis(fc *flowchart.Flowchart, graph *DepGraph, analysis DependencyAnalysis) {
+	inCycle := map[string]bool{}
+	for _, cycle := range analysis.Cycles {
+		for _, key := range cycle {
+			inCycle[key] = true
+		}
+	}
+
+	for key := range inCycle {
+		node := fc.GetNode(key)
+		if node == nil {
+			continue
+		}
+
+		style := cycleFill
+		node.Style = &style
+
+		if len(node.Text) > 0 {
+			node.Text[0] = "⚠ " + node.Text[0]
+		}
+	}
+
+	// Only a "Blocks" edge whose endpoints fall in the *same* SCC is
+	// actually part of a cycle; an edge that merely connects two
+	// unrelated cycles (or isn't a blocking link at all) is left alone.
+	// Styling the specific *flowchart.Edge behind each GraphEdge (rather
+	// than matching fc.ListEdges() by endpoint IDs) keeps this from also
+	// painting an unrelated second edge between the same two issues, e.g.
+	// a "Relates" link alongside the "Blocks" cycle edge.
+	for _, e := range graph.Edges {
+		if !strings.EqualFold(e.Type, "Blocks") {
+			continue
+		}
+
+		fromSCC, ok := analysis.sccOf[e.From]
+		if !ok || fromSCC != analysis.sccOf[e.To] {
+			continue
+		}
+
+		style := criticalEdge
+		style.Stroke = `#FF4136`
+		e.fcEdge.Style = &style
+	}
+
+	for i := 0; i+1 < len(analysis.CriticalPath); i++ {
+		from, to := analysis.CriticalPath[i], analysis.CriticalPath[i+1]
+
+		for _, e := range graph.Edges {
+			if e.From == from && e.To == to && strings.EqualFold(e.Type, "Blocks") {
+				style := criticalEdge
+				e.fcEdge.Style = &style
+			}
+		}
+	}
+}
+
+// SummarizeAnalysis renders the "⚠ N cycles detected: ..." line and the
+// "Critical path length L: ..." line (each only when it applies, one per
+// line) printed above the mermaid block.
+func SummarizeAnalysis(analysis DependencyAnalysis) string {
+	var b strings.Builder
+
+	if len(analysis.Cycles) > 0 {
+		fmt.Fprintf(&b, "⚠ %d cycle", len(analysis.Cycles))
+		if len(analysis.Cycles) != 1 {
+			b.WriteString("s")
+		}
+		b.WriteString(" detected: ")
+
+		for i, cycle := range analysis.Cycles {
+			if i > 0 {
+				b.WriteString("; ")
+			}
+			fmt.Fprintf(&b, "[%s]", strings.Join(cycle, " → "))
+		}
+	}
+
+	if len(analysis.CriticalPath) > 1 {
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "Critical path length %d: %s", len(analysis.CriticalPath)-1, strings.Join(analysis.CriticalPath, " → "))
+	}
+
+	return b.String()
+}
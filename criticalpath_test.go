@@ -0,0 +1,224 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/Heiko-san/mermaidgen/flowchart"
+)
+
+func sortedSCCs(sccs [][]string) [][]string {
+	out := make([][]string, len(sccs))
+	for i, scc := range sccs {
+		cp := append([]string(nil), scc...)
+		sort.Strings(cp)
+		out[i] = cp
+	}
+
+	sort.Slice(out, func(i, j int) bool { return out[i][0] < out[j][0] })
+
+	return out
+}
+
+func TestTarjanSCC(t *testing.T) {
+	tests := []struct {
+		name string
+		adj  map[string][]string
+		want [][]string
+	}{
+		{
+			name: "linear chain has no cycles",
+			adj: map[string][]string{
+				"A": {"B"},
+				"B": {"C"},
+			},
+			want: [][]string{{"A"}, {"B"}, {"C"}},
+		},
+		{
+			name: "two-node cycle",
+			adj: map[string][]string{
+				"A": {"B"},
+				"B": {"A"},
+			},
+			want: [][]string{{"A", "B"}},
+		},
+		{
+			name: "self-loop",
+			adj: map[string][]string{
+				"A": {"A"},
+			},
+			want: [][]string{{"A"}},
+		},
+		{
+			name: "two separate cycles joined by a bridge edge",
+			adj: map[string][]string{
+				"A1": {"A2"},
+				"A2": {"A1", "B1"},
+				"B1": {"B2"},
+				"B2": {"B1"},
+			},
+			want: [][]string{{"A1", "A2"}, {"B1", "B2"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := sortedSCCs(tarjanSCC(tt.adj))
+			want := sortedSCCs(tt.want)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("tarjanSCC(%v) = %v, want %v", tt.adj, got, want)
+			}
+		})
+	}
+}
+
+func TestCriticalPathTo(t *testing.T) {
+	// A -> B -> D, A -> C -> D: two paths of equal length into D, plus an
+	// unrelated two-node cycle X<->Y hanging off B that has no path to D and
+	// so must not end up on the critical path.
+	adj := map[string][]string{
+		"A": {"B", "C"},
+		"B": {"D", "X"},
+		"C": {"D"},
+		"X": {"Y"},
+		"Y": {"X"},
+	}
+	sccs := tarjanSCC(adj)
+	nodeSCC := make(map[string]int, len(sccs))
+	for id, scc := range sccs {
+		for _, key := range scc {
+			nodeSCC[key] = id
+		}
+	}
+
+	got := criticalPathTo(adj, nodeSCC, sccs, "D")
+	if len(got) != 3 {
+		t.Fatalf("criticalPathTo(..., %q) = %v, want a length-3 path ending at D", "D", got)
+	}
+	if got[len(got)-1] != "D" {
+		t.Errorf("criticalPathTo(...) ends at %q, want D", got[len(got)-1])
+	}
+	if got[0] != "A" {
+		t.Errorf("criticalPathTo(...) starts at %q, want A", got[0])
+	}
+}
+
+func TestCriticalPathToUnknownTarget(t *testing.T) {
+	adj := map[string][]string{"A": {"B"}}
+	sccs := tarjanSCC(adj)
+	nodeSCC := make(map[string]int, len(sccs))
+	for id, scc := range sccs {
+		for _, key := range scc {
+			nodeSCC[key] = id
+		}
+	}
+
+	if got := criticalPathTo(adj, nodeSCC, sccs, "NOPE"); got != nil {
+		t.Errorf("criticalPathTo(..., %q) = %v, want nil", "NOPE", got)
+	}
+}
+
+func TestAnalyzeDependenciesClosesCycles(t *testing.T) {
+	tests := []struct {
+		name string
+		adj  map[string][]string
+		want []string
+	}{
+		{
+			name: "self-loop",
+			adj:  map[string][]string{"ABC-1": {"ABC-1"}},
+			want: []string{"ABC-1", "ABC-1"},
+		},
+		{
+			name: "two-node mutual block",
+			adj: map[string][]string{
+				"ABC-1": {"ABC-4"},
+				"ABC-4": {"ABC-1"},
+			},
+			want: []string{"ABC-1", "ABC-4", "ABC-1"},
+		},
+		{
+			name: "three-node cycle",
+			adj: map[string][]string{
+				"ABC-1": {"ABC-2"},
+				"ABC-2": {"ABC-3"},
+				"ABC-3": {"ABC-1"},
+			},
+			want: []string{"ABC-1", "ABC-2", "ABC-3", "ABC-1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			graph := NewDepGraph()
+			for from, tos := range tt.adj {
+				for _, to := range tos {
+					graph.AddEdge(GraphEdge{From: from, To: to, Type: "Blocks"})
+				}
+			}
+
+			analysis := AnalyzeDependencies(graph, "")
+			if len(analysis.Cycles) != 1 {
+				t.Fatalf("AnalyzeDependencies(...).Cycles = %v, want exactly one cycle", analysis.Cycles)
+			}
+
+			got := analysis.Cycles[0]
+			if got[0] != got[len(got)-1] {
+				t.Errorf("cycle %v does not close back to its start", got)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("cycle = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCycleOrderLargeSCCFallsBackToShortestCycle(t *testing.T) {
+	// A ring of 20 nodes (above hamiltonianCycleSearchLimit) plus a direct
+	// edge back from the last node to the first: cycleOrder must still
+	// close the loop, just not necessarily via every node.
+	adj := map[string][]string{}
+	scc := make([]string, 20)
+	for i := 0; i < 20; i++ {
+		scc[i] = fmt.Sprintf("N%02d", i)
+	}
+	for i, from := range scc {
+		to := scc[(i+1)%len(scc)]
+		adj[from] = append(adj[from], to)
+	}
+
+	got := cycleOrder(adj, scc)
+	if len(got) < 2 || got[0] != got[len(got)-1] {
+		t.Fatalf("cycleOrder(...) = %v, want a closed cycle", got)
+	}
+}
+
+func TestHighlightAnalysisOnlyStylesTheCycleEdgeNotALookalike(t *testing.T) {
+	fc := flowchart.NewFlowchart()
+	a := fc.AddNode("ABC-1")
+	b := fc.AddNode("ABC-4")
+
+	blocksAB := fc.AddEdge(a, b)
+	blocksBA := fc.AddEdge(b, a)
+	relatesAB := fc.AddEdge(a, b)
+
+	graph := NewDepGraph()
+	graph.AddEdge(GraphEdge{From: "ABC-1", To: "ABC-4", Type: "Blocks", fcEdge: blocksAB})
+	graph.AddEdge(GraphEdge{From: "ABC-4", To: "ABC-1", Type: "Blocks", fcEdge: blocksBA})
+	graph.AddEdge(GraphEdge{From: "ABC-1", To: "ABC-4", Type: "Relates", fcEdge: relatesAB})
+
+	analysis := AnalyzeDependencies(graph, "")
+	HighlightAnalysis(fc, graph, analysis)
+
+	if blocksAB.Style == nil {
+		t.Error("blocksAB.Style is nil, want the cycle edge styled")
+	}
+	if blocksBA.Style == nil {
+		t.Error("blocksBA.Style is nil, want the cycle edge styled")
+	}
+	if relatesAB.Style != nil {
+		t.Error("relatesAB.Style is set; the lookalike Relates edge must not be painted as a cycle edge")
+	}
+}
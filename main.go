@@ -2,11 +2,9 @@ package main
 
 import (
 	"context"
-	"encoding/json"
-	"errors"
+	"flag"
 	"fmt"
 	"html"
-	"io/fs"
 	"maps"
 	"net/url"
 	"os"
@@ -20,109 +18,6 @@ import (
 	"github.com/charmbracelet/huh"
 )
 
-type AuthCreds struct {
-	Username string
-	Token    string
-	BaseURL  string
-}
-
-var baseURL string
-
-var ErrIncompleteCredentials = errors.New("must provide 'username' and 'token' keys in file")
-
-func getAuthCreds() (creds AuthCreds, err error) {
-	fileName := os.ExpandEnv("${HOME}/.config/jiradeps.json")
-	var newCreds bool
-	file, err := os.ReadFile(fileName)
-
-	usernameInput := huh.NewInput().
-		Title("Username").
-		Value(&creds.Username).
-		Validate(func(s string) error {
-			if s == "" {
-				return fmt.Errorf("Username cannot be empty")
-			}
-			return nil
-		})
-
-	tokenInput := huh.NewInput().
-		Title("API Token").
-		Value(&creds.Token).
-		Validate(func(s string) error {
-			if s == "" {
-				return fmt.Errorf("API token cannot be empty")
-			}
-			if len(s) < 190 {
-				return fmt.Errorf("API token must be at least 190 characters")
-			}
-			if len(s) > 200 {
-				return fmt.Errorf("API token must be at most 200 characters")
-			}
-			if strings.Contains(s, "\"") {
-				return fmt.Errorf("API token must not contain quotes")
-			}
-			return nil
-		})
-
-	baseUrlInput := huh.NewInput().
-		Title("Base URL").
-		Value(&creds.BaseURL).
-		Validate(func(s string) error {
-			if s == "" {
-				return fmt.Errorf("Base URL cannot be empty")
-			}
-			if !strings.HasSuffix(s, "/") {
-				return fmt.Errorf("Base URL must end with a slash")
-			}
-			if !strings.HasPrefix(s, "https://") {
-				return fmt.Errorf("Base URL must start with 'https://'")
-			}
-			return nil
-		})
-
-	if err != nil {
-		if errors.Is(err, fs.ErrNotExist) {
-			// If the file does not exist, prompt for credentials
-			huh.NewForm(huh.NewGroup(baseUrlInput, usernameInput, tokenInput).Title("Jira Credentials")).Run()
-			newCreds = true
-		} else {
-			return creds, fmt.Errorf("reading file: %w", err)
-		}
-	}
-
-	if !newCreds {
-		if err = json.Unmarshal(file, &creds); err != nil {
-			return creds, fmt.Errorf("unmarshalling file: %w", err)
-		}
-	}
-
-	if creds.Username == "" {
-		usernameInput.Run()
-		newCreds = true
-	}
-	if creds.Token == "" {
-		tokenInput.Run()
-		newCreds = true
-	}
-	if creds.BaseURL == "" {
-		baseUrlInput.Run()
-		newCreds = true
-	}
-	baseURL = creds.BaseURL
-
-	if newCreds {
-		jsonData, err := json.MarshalIndent(creds, "", "  ")
-		if err != nil {
-			return creds, fmt.Errorf("marshalling credentials: %w", err)
-		}
-		if err = os.WriteFile(fileName, jsonData, 0o600); err != nil {
-			return creds, fmt.Errorf("writing file: %w", err)
-		}
-	}
-
-	return creds, nil
-}
-
 type StringSet map[string]struct{}
 
 func (s StringSet) Add(n string) {
@@ -216,7 +111,7 @@ func GetStatusIcon(status string) (icon string) {
 	return icon
 }
 
-func AddJiraNode(fc *flowchart.Flowchart, issue *jira.Issue) (node *flowchart.Node) {
+func AddJiraNode(fc *flowchart.Flowchart, graph *DepGraph, issue *jira.Issue) (node *flowchart.Node) {
 	node = fc.GetNode(issue.Key)
 	if node == nil {
 		node = fc.AddNode(issue.Key)
@@ -230,14 +125,23 @@ func AddJiraNode(fc *flowchart.Flowchart, issue *jira.Issue) (node *flowchart.No
 			fmt.Sprintf("%s %s - %s", GetStatusIcon(status), issue.Key, status),
 			strings.ReplaceAll(html.EscapeString(text), "&#", "#"),
 		)
+
+		graph.AddNode(GraphNode{
+			Key:      issue.Key,
+			Summary:  text,
+			Status:   status,
+			Type:     issue.Fields.Type.Name,
+			Priority: issue.Fields.Priority.Name,
+			URL:      node.Link,
+		})
 	}
 
 	return node
 }
 
-func AddLink(fc *flowchart.Flowchart, link JiraLink) {
-	n1 := AddJiraNode(fc, link.From)
-	n2 := AddJiraNode(fc, link.To)
+func AddLink(fc *flowchart.Flowchart, graph *DepGraph, link JiraLink) {
+	n1 := AddJiraNode(fc, graph, link.From)
+	n2 := AddJiraNode(fc, graph, link.To)
 	e := fc.AddEdge(n1, n2)
 	e.Text = []string{link.Link.Type.Outward}
 
@@ -246,46 +150,24 @@ func AddLink(fc *flowchart.Flowchart, link JiraLink) {
 	if strings.EqualFold(link.Link.Type.Name, "relates") {
 		e.Shape = flowchart.EShapeThickLine
 	}
-}
 
-func getAllLinks(issue *jira.Issue, client *jira.Client, links StringSet, fc *flowchart.Flowchart) {
-	if len(issue.Fields.IssueLinks) == 0 {
-		issue, _, _ = client.Issue.Get(issue.Key, nil)
-	}
-
-	for _, link := range issue.Fields.IssueLinks {
-		if link.OutwardIssue != nil {
-			lo := JiraLink{
-				From: issue,
-				Link: link,
-				To:   link.OutwardIssue,
-			}
-			if !links.Exists(lo.String()) {
-				AddLink(fc, lo)
-				links.Add(lo.String())
-				getAllLinks(link.OutwardIssue, client, links, fc)
-			}
-		}
-
-		if link.InwardIssue != nil {
-			li := JiraLink{
-				From: link.InwardIssue,
-				Link: link,
-				To:   issue,
-			}
-			if !links.Exists(li.String()) {
-				AddLink(fc, li)
-				links.Add(li.String())
-				getAllLinks(link.InwardIssue, client, links, fc)
-			}
-		}
-	}
+	graph.AddEdge(GraphEdge{
+		From:    link.From.Key,
+		To:      link.To.Key,
+		Type:    link.Link.Type.Name,
+		Inward:  link.Link.Type.Inward,
+		Outward: link.Link.Type.Outward,
+		fcEdge:  e,
+	})
 }
 
-// SearchWithContext performs a JQL search and returns a list of issue IDs that match the query.
+// SearchWithContext performs a JQL search and returns a list of issue keys that match the query.
+// Keys are returned (rather than numeric issue IDs) so that the result can be
+// used directly as a cache key by BulkFetchIssues, matching what FileCache.Put
+// stores issues under.
 // Uses the v3 API as the v2 API is deprecated, but go-jira hasn't been updated to reflect this.
 // It handles pagination using the nextPageToken field.
-func SearchWithContext(ctx context.Context, client *jira.Client, jql string, options *map[string]any) (issueIDs []string, err error) {
+func SearchWithContext(ctx context.Context, client *jira.Client, jql string, options *map[string]any) (issueKeys []string, err error) {
 	u := url.URL{
 		Path: "rest/api/3/search/jql",
 	}
@@ -316,7 +198,7 @@ func SearchWithContext(ctx context.Context, client *jira.Client, jql string, opt
 		}
 
 		for _, issue := range v.Issues {
-			issueIDs = append(issueIDs, issue.ID)
+			issueKeys = append(issueKeys, issue.Key)
 		}
 
 		if v.NextPageToken == "" {
@@ -325,14 +207,29 @@ func SearchWithContext(ctx context.Context, client *jira.Client, jql string, opt
 		body["nextPageToken"] = v.NextPageToken
 	}
 
-	return issueIDs, err
+	return issueKeys, err
 }
 
-// BulkFetchIssues fetches multiple issues by their IDs or keys using the bulkfetch endpoint.
+// BulkFetchIssues fetches multiple issues by their keys using the bulkfetch endpoint.
 // It retrieves specific fields for each issue to minimize data transfer.
 // Uses the v3 API as the v2 API is deprecated, but go-jira hasn't been updated to reflect this.
-func BulkFetchIssues(ctx context.Context, client *jira.Client, issueIDs []string) (issues []jira.Issue, err error) {
-	if len(issueIDs) == 0 {
+// cache is consulted first; only keys missing from cache are sent to Jira, and
+// freshly fetched issues are written back into it. In offline mode a cache
+// miss is an error, matching getIssueCached, rather than being silently dropped.
+func BulkFetchIssues(ctx context.Context, client *jira.Client, issueKeys []string, cache Cache, offline bool) (issues []jira.Issue, err error) {
+	var toFetch []string
+
+	for _, key := range issueKeys {
+		if issue, ok := cache.Get(key); ok {
+			issues = append(issues, *issue)
+		} else if offline {
+			return nil, fmt.Errorf("offline mode: %s not in cache", key)
+		} else {
+			toFetch = append(toFetch, key)
+		}
+	}
+
+	if len(toFetch) == 0 {
 		return issues, nil
 	}
 
@@ -342,7 +239,7 @@ func BulkFetchIssues(ctx context.Context, client *jira.Client, issueIDs []string
 
 	body := map[string]any{
 		"fields":         []string{"issuelinks", "labels", "priority", "status", "summary", "issuetype"},
-		"issueIdsOrKeys": issueIDs,
+		"issueIdsOrKeys": toFetch,
 	}
 	req, err := client.NewRequestWithContext(ctx, "POST", u.String(), &body)
 	if err != nil {
@@ -356,18 +253,23 @@ func BulkFetchIssues(ctx context.Context, client *jira.Client, issueIDs []string
 	v := new(fetchResult)
 	resp, err := client.Do(req, &v)
 	if err != nil {
-		err = jira.NewJiraError(resp, err)
+		return issues, jira.NewJiraError(resp, err)
+	}
+
+	for _, issue := range v.Issues {
+		cache.Put(&issue)
 	}
-	return v.Issues, err
+
+	return append(issues, v.Issues...), nil
 }
 
-func SearchAndFetch(ctx context.Context, client *jira.Client, jql string, options *map[string]any) (issues []jira.Issue, err error) {
-	issueIDs, err := SearchWithContext(ctx, client, jql, options)
+func SearchAndFetch(ctx context.Context, client *jira.Client, jql string, options *map[string]any, cache Cache, offline bool) (issues []jira.Issue, err error) {
+	issueKeys, err := SearchWithContext(ctx, client, jql, options)
 	if err != nil {
 		return nil, fmt.Errorf("error searching for issues: %w", err)
 	}
 
-	issues, err = BulkFetchIssues(ctx, client, issueIDs)
+	issues, err = BulkFetchIssues(ctx, client, issueKeys, cache, offline)
 	if err != nil {
 		return nil, fmt.Errorf("error bulk fetching child issues: %w", err)
 	}
@@ -375,12 +277,12 @@ func SearchAndFetch(ctx context.Context, client *jira.Client, jql string, option
 	return issues, nil
 }
 
-func genDepFlowchart(c *jira.Client, issueNum string, fc *flowchart.Flowchart) error {
-	linkSet := StringSet{}
-
-	issue, _, err := c.Issue.Get(strings.TrimSpace(issueNum), nil)
+// genDepFlowchart populates fc/graph with issueNum's dependency closure and
+// returns its canonical issue key, used as the critical-path target.
+func genDepFlowchart(c *jira.Client, issueNum string, fc *flowchart.Flowchart, graph *DepGraph, cache Cache, offline bool, concurrency int) (string, error) {
+	issue, err := getIssueCached(c, cache, offline, strings.TrimSpace(issueNum))
 	if err != nil {
-		return fmt.Errorf("error getting issue: %w", err)
+		return "", fmt.Errorf("error getting issue: %w", err)
 	}
 
 	fmt.Printf("\n%s: %+v\n", issue.Key, issue.Fields.Summary)
@@ -388,19 +290,110 @@ func genDepFlowchart(c *jira.Client, issueNum string, fc *flowchart.Flowchart) e
 	fmt.Printf("Priority: %s\n", issue.Fields.Priority.Name)
 	fmt.Printf("Links: ")
 
+	seeds := []*jira.Issue{issue}
+
 	if issue.Fields.Type.Name == "Epic" {
-		issues, err := SearchAndFetch(context.Background(), c, fmt.Sprintf("parentEpic = %s", issue.Key), nil)
+		issues, err := SearchAndFetch(context.Background(), c, fmt.Sprintf("parentEpic = %s", issue.Key), nil, cache, offline)
 		if err != nil {
-			return fmt.Errorf("error searching for child issues: %w", err)
+			return "", fmt.Errorf("error searching for child issues: %w", err)
 		}
-		for _, childIssue := range issues {
-			getAllLinks(&childIssue, c, linkSet, fc)
+		seeds = seeds[:0]
+		for i := range issues {
+			seeds = append(seeds, &issues[i])
 		}
-	} else {
-		getAllLinks(issue, c, linkSet, fc)
 	}
 
-	return nil
+	return issue.Key, traverseLinks(c, seeds, fc, graph, cache, offline, concurrency)
+}
+
+// genDepFlowchartForQuery builds a single combined flowchart of every issue
+// matched by jql plus their dependency closures, deduplicated. There's no
+// single target issue for a query, so critical-path highlighting is skipped.
+func genDepFlowchartForQuery(c *jira.Client, jql string, fc *flowchart.Flowchart, graph *DepGraph, cache Cache, offline bool, concurrency int) error {
+	fmt.Printf("\nQuery: %s\n", jql)
+
+	issues, err := SearchAndFetch(context.Background(), c, jql, nil, cache, offline)
+	if err != nil {
+		return fmt.Errorf("error running query: %w", err)
+	}
+
+	fmt.Printf("Matched %d issues\n", len(issues))
+	fmt.Printf("Links: ")
+
+	seeds := make([]*jira.Issue, len(issues))
+	for i := range issues {
+		seeds[i] = &issues[i]
+	}
+
+	return traverseLinks(c, seeds, fc, graph, cache, offline, concurrency)
+}
+
+// resolveQuery reports whether arg is a "jql:<query>" or "@<saved-query>"
+// argument rather than a plain issue key, returning the JQL to run for it.
+func resolveQuery(creds AuthCreds, arg string) (jql string, isQuery bool, err error) {
+	switch {
+	case strings.HasPrefix(arg, "jql:"):
+		return strings.TrimPrefix(arg, "jql:"), true, nil
+	case strings.HasPrefix(arg, "@"):
+		name := strings.TrimPrefix(arg, "@")
+
+		jql, ok := creds.SavedQueries[name]
+		if !ok {
+			return "", true, fmt.Errorf("no saved query named %q", name)
+		}
+
+		return jql, true, nil
+	default:
+		return "", false, nil
+	}
+}
+
+// sanitizeLabel turns an issue key, "jql:..." query, or "@name" saved query
+// into a string safe to use as an output file name or DOT graph name.
+func sanitizeLabel(label string) string {
+	label = strings.TrimPrefix(label, "jql:")
+	label = strings.TrimPrefix(label, "@")
+
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_' {
+			return r
+		}
+
+		return '_'
+	}, label)
+}
+
+// promptForArg offers a picker over any saved queries, falling back to a
+// plain issue number prompt if none are configured or the user opts out.
+func promptForArg(creds AuthCreds) string {
+	if len(creds.SavedQueries) == 0 {
+		return promptForIssue()
+	}
+
+	names := make([]string, 0, len(creds.SavedQueries))
+	for name := range creds.SavedQueries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	const manualEntry = ""
+	options := make([]huh.Option[string], 0, len(names)+1)
+	for _, name := range names {
+		options = append(options, huh.NewOption(name, "@"+name))
+	}
+	options = append(options, huh.NewOption("Enter issue number...", manualEntry))
+
+	var choice string
+	huh.NewSelect[string]().
+		Title("Saved Query").
+		Options(options...).
+		Value(&choice).Run()
+
+	if choice == manualEntry {
+		return promptForIssue()
+	}
+
+	return choice
 }
 
 func promptForIssue() (issueNum string) {
@@ -446,49 +439,110 @@ func imgURL(url string, format string) string {
 	return strings.ReplaceAll(url, "mermaid.live/view/#pako", "mermaid.ink/img/pako") + "?type=" + format
 }
 
+func renderOutput(renderer Renderer, in RenderInput, outputDir string) error {
+	if outputDir == "" {
+		return renderer.Render(in, os.Stdout)
+	}
+
+	if err := os.MkdirAll(outputDir, 0o755); err != nil {
+		return fmt.Errorf("creating output directory: %w", err)
+	}
+
+	fileName := fmt.Sprintf("%s.%s", in.Issue, FileExtension(*formatFlag))
+	f, err := os.Create(fmt.Sprintf("%s/%s", outputDir, fileName))
+	if err != nil {
+		return fmt.Errorf("creating output file: %w", err)
+	}
+	defer f.Close()
+
+	return renderer.Render(in, f)
+}
+
+var (
+	formatFlag      = flag.String("format", "mermaid", "output format: mermaid, svg, png, dot, or json")
+	outputFlag      = flag.String("output", "", "directory to write output files to (default: stdout)")
+	offlineFlag     = flag.Bool("offline", false, "only use cached issues, never call the Jira API")
+	cacheTTL        = flag.Duration("cache-ttl", DefaultCacheTTL, "how long cached issues stay fresh")
+	concurrencyFlag = flag.Int("concurrency", DefaultConcurrency, "max issues whose links are expanded in parallel (each level is fetched in one bulk request)")
+)
+
 func main() {
+	flag.Parse()
+
+	renderer, ok := Renderers[*formatFlag]
+	if !ok {
+		fmt.Printf("Unknown format %q\n", *formatFlag)
+		os.Exit(1)
+	}
+
+	if *concurrencyFlag <= 0 {
+		fmt.Printf("Invalid -concurrency %d: must be greater than 0\n", *concurrencyFlag)
+		os.Exit(1)
+	}
+
 	jiraAuth, err := getAuthCreds()
 	if err != nil {
 		fmt.Printf("Error getting credentials: %v\n", err)
 		os.Exit(1)
 	}
 
-	tp := jira.BasicAuthTransport{
-		Username: jiraAuth.Username,
-		Password: jiraAuth.Token,
+	jiraClient, err := jiraClientForCreds(jiraAuth)
+	if err != nil {
+		fmt.Printf("Error making client: %v\n", err)
+		os.Exit(1)
 	}
 
-	jiraClient, err := jira.NewClient(tp.Client(), baseURL)
+	cache, err := NewFileCache(jiraAuth.BaseURL, *cacheTTL, *offlineFlag)
 	if err != nil {
-		fmt.Printf("Error making client: %v\n", err)
+		fmt.Printf("Error setting up cache: %v\n", err)
 		os.Exit(1)
 	}
 
 	var issues []string
-	if len(os.Args) > 1 {
-		issues = os.Args[1:]
+	if flag.NArg() > 0 {
+		issues = flag.Args()
 	} else {
-		issues = append(issues, promptForIssue())
+		issues = append(issues, promptForArg(jiraAuth))
 	}
 
 	var returnCode int
 
-	for _, issueNum := range issues {
+	for _, arg := range issues {
 		flow := flowchart.NewFlowchart()
+		graph := NewDepGraph()
 
-		err := genDepFlowchart(jiraClient, issueNum, flow)
+		jql, isQuery, err := resolveQuery(jiraAuth, arg)
 		if err != nil {
 			fmt.Println(err)
 			returnCode++
+
+			continue
 		}
 
-		if len(flow.ListNodes()) > 1 {
-			fmt.Printf("\n\n```mermaid\n---\nconfig:\n  theme: neutral\n---\n%s```\n\n", flow.String())
-			fmt.Printf("Live: %s\n\n", flow.LiveURL())
-			fmt.Printf("PNG:  %s\n\n", imgURL(flow.LiveURL(), "png"))
-			fmt.Printf("SVG:  %s\n\n", imgURL(flow.LiveURL(), "svg"))
+		var target string
+		if isQuery {
+			err = genDepFlowchartForQuery(jiraClient, jql, flow, graph, cache, *offlineFlag, *concurrencyFlag)
 		} else {
-			fmt.Println("None")
+			target, err = genDepFlowchart(jiraClient, arg, flow, graph, cache, *offlineFlag, *concurrencyFlag)
+		}
+		if err != nil {
+			fmt.Println(err)
+			returnCode++
+
+			continue
+		}
+
+		analysis := AnalyzeDependencies(graph, target)
+		HighlightAnalysis(flow, graph, analysis)
+
+		if summary := SummarizeAnalysis(analysis); summary != "" {
+			fmt.Println(summary)
+		}
+
+		in := RenderInput{Flowchart: flow, Graph: graph, Issue: sanitizeLabel(strings.TrimSpace(arg))}
+		if err := renderOutput(renderer, in, *outputFlag); err != nil {
+			fmt.Println(err)
+			returnCode++
 		}
 	}
 
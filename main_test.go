@@ -0,0 +1,106 @@
+package main
+
+import "testing"
+
+func TestResolveQuery(t *testing.T) {
+	creds := AuthCreds{SavedQueries: map[string]string{"mine": "assignee = currentUser()"}}
+
+	tests := []struct {
+		name        string
+		arg         string
+		wantJQL     string
+		wantIsQuery bool
+		wantErr     bool
+	}{
+		{
+			name:        "jql prefix",
+			arg:         "jql:project = ABC",
+			wantJQL:     "project = ABC",
+			wantIsQuery: true,
+		},
+		{
+			name:        "saved query",
+			arg:         "@mine",
+			wantJQL:     "assignee = currentUser()",
+			wantIsQuery: true,
+		},
+		{
+			name:        "unknown saved query",
+			arg:         "@nope",
+			wantIsQuery: true,
+			wantErr:     true,
+		},
+		{
+			name: "plain issue key",
+			arg:  "ABC-123",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jql, isQuery, err := resolveQuery(creds, tt.arg)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("resolveQuery(%q) = nil error, want an error", tt.arg)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("resolveQuery(%q): %v", tt.arg, err)
+			}
+			if jql != tt.wantJQL {
+				t.Errorf("resolveQuery(%q) jql = %q, want %q", tt.arg, jql, tt.wantJQL)
+			}
+			if isQuery != tt.wantIsQuery {
+				t.Errorf("resolveQuery(%q) isQuery = %v, want %v", tt.arg, isQuery, tt.wantIsQuery)
+			}
+		})
+	}
+}
+
+func TestSanitizeLabel(t *testing.T) {
+	tests := []struct {
+		name  string
+		label string
+		want  string
+	}{
+		{name: "plain issue key", label: "ABC-123", want: "ABC-123"},
+		{name: "jql prefix stripped, unsafe chars replaced", label: `jql:project = "ABC"`, want: "project____ABC_"},
+		{name: "saved query prefix stripped", label: "@mine", want: "mine"},
+		{name: "unicode letters kept", label: "café", want: "café"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sanitizeLabel(tt.label); got != tt.want {
+				t.Errorf("sanitizeLabel(%q) = %q, want %q", tt.label, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestPromptForArgDoesNotPanicHeadless exercises promptForArg's saved-query
+// dispatch logic without a TTY: huh.Run() fails fast with "could not open a
+// new TTY" instead of blocking, so both the saved-query and no-saved-query
+// branches must tolerate that error rather than panicking.
+func TestPromptForArgDoesNotPanicHeadless(t *testing.T) {
+	tests := []struct {
+		name  string
+		creds AuthCreds
+	}{
+		{name: "no saved queries", creds: AuthCreds{}},
+		{name: "with saved queries", creds: AuthCreds{SavedQueries: map[string]string{"mine": "assignee = currentUser()"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if r := recover(); r != nil {
+					t.Fatalf("promptForArg panicked: %v", r)
+				}
+			}()
+
+			promptForArg(tt.creds)
+		})
+	}
+}
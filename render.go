@@ -0,0 +1,231 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/Heiko-san/mermaidgen/flowchart"
+)
+
+// GraphNode is the JSON-friendly representation of a single flowchart node.
+type GraphNode struct {
+	Key      string `json:"key"`
+	Summary  string `json:"summary"`
+	Status   string `json:"status"`
+	Type     string `json:"type"`
+	Priority string `json:"priority"`
+	URL      string `json:"url"`
+}
+
+// GraphEdge is the JSON-friendly representation of a single flowchart edge.
+type GraphEdge struct {
+	From    string `json:"from"`
+	To      string `json:"to"`
+	Type    string `json:"type"`
+	Inward  string `json:"inward"`
+	Outward string `json:"outward"`
+
+	// fcEdge is the specific *flowchart.Edge AddLink created for this
+	// link. flowchart.AddEdge never dedups, so two issues can have more
+	// than one edge between them (e.g. a "Blocks" and a "Relates" link);
+	// HighlightAnalysis needs this pointer to style the right one rather
+	// than matching by endpoint IDs alone. Unexported so it stays out of
+	// the "json" renderer's output.
+	fcEdge *flowchart.Edge
+}
+
+// DepGraph accumulates the raw node/edge metadata collected while walking
+// Jira issue links, independently of the flowchart.Flowchart used to render
+// the mermaid diagram. It backs the "json" output format.
+type DepGraph struct {
+	Nodes   []GraphNode
+	Edges   []GraphEdge
+	nodeIdx map[string]int
+}
+
+func NewDepGraph() *DepGraph {
+	return &DepGraph{nodeIdx: map[string]int{}}
+}
+
+// AddNode records node, replacing any earlier entry for the same key.
+func (g *DepGraph) AddNode(node GraphNode) {
+	if i, ok := g.nodeIdx[node.Key]; ok {
+		g.Nodes[i] = node
+		return
+	}
+
+	g.nodeIdx[node.Key] = len(g.Nodes)
+	g.Nodes = append(g.Nodes, node)
+}
+
+func (g *DepGraph) AddEdge(edge GraphEdge) {
+	g.Edges = append(g.Edges, edge)
+}
+
+// RenderInput bundles everything a Renderer needs to produce output for one
+// issue's dependency traversal.
+type RenderInput struct {
+	Flowchart *flowchart.Flowchart
+	Graph     *DepGraph
+	Issue     string
+}
+
+// Renderer turns a completed dependency graph into bytes written to w.
+type Renderer interface {
+	Render(in RenderInput, w io.Writer) error
+}
+
+// Renderers maps the -format flag values to their implementations.
+var Renderers = map[string]Renderer{
+	"mermaid": MermaidRenderer{},
+	"svg":     ImageRenderer{Format: "svg"},
+	"png":     ImageRenderer{Format: "png"},
+	"dot":     DotRenderer{},
+	"json":    JSONRenderer{},
+}
+
+// FileExtension returns the conventional file extension for the -format
+// flag's value, used to name output files when -output is a directory.
+func FileExtension(format string) string {
+	if format == "mermaid" {
+		return "md"
+	}
+
+	return format
+}
+
+// MermaidRenderer prints the mermaid diagram source plus mermaid.live/ink
+// links, matching jiradeps' original console output.
+type MermaidRenderer struct{}
+
+func (MermaidRenderer) Render(in RenderInput, w io.Writer) error {
+	if len(in.Flowchart.ListNodes()) <= 1 {
+		fmt.Fprintln(w, "None")
+		return nil
+	}
+
+	fmt.Fprintf(w, "\n\n```mermaid\n---\nconfig:\n  theme: neutral\n---\n%s```\n\n", in.Flowchart.String())
+	fmt.Fprintf(w, "Live: %s\n\n", in.Flowchart.LiveURL())
+	fmt.Fprintf(w, "PNG:  %s\n\n", imgURL(in.Flowchart.LiveURL(), "png"))
+	fmt.Fprintf(w, "SVG:  %s\n\n", imgURL(in.Flowchart.LiveURL(), "svg"))
+
+	return nil
+}
+
+// JSONRenderer emits the collected DepGraph as {nodes:[...],edges:[...]}.
+type JSONRenderer struct{}
+
+func (JSONRenderer) Render(in RenderInput, w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(struct {
+		Nodes []GraphNode `json:"nodes"`
+		Edges []GraphEdge `json:"edges"`
+	}{in.Graph.Nodes, in.Graph.Edges})
+}
+
+// DotRenderer emits a Graphviz "digraph" of the flowchart's nodes and edges.
+type DotRenderer struct{}
+
+func (DotRenderer) Render(in RenderInput, w io.Writer) error {
+	fmt.Fprintf(w, "digraph %q {\n", in.Issue)
+
+	for _, node := range in.Flowchart.ListNodes() {
+		label := node.ID()
+		if len(node.Text) > 0 {
+			label = strings.Join(node.Text, "\\n")
+		}
+		fmt.Fprintf(w, "  %q [label=%q];\n", node.ID(), label)
+	}
+
+	for _, edge := range in.Flowchart.ListEdges() {
+		label := strings.Join(edge.Text, ", ")
+		fmt.Fprintf(w, "  %q -> %q [label=%q];\n", edge.From.ID(), edge.To.ID(), label)
+	}
+
+	fmt.Fprintln(w, "}")
+
+	return nil
+}
+
+// ImageRenderer produces a PNG or SVG rendering of the mermaid diagram. It
+// prefers a locally installed mmdc (mermaid-cli), falling back to fetching
+// the rendered image from mermaid.ink when mmdc isn't on $PATH.
+type ImageRenderer struct {
+	Format string
+}
+
+func (r ImageRenderer) Render(in RenderInput, w io.Writer) error {
+	if len(in.Flowchart.ListNodes()) <= 1 {
+		return fmt.Errorf("no dependency graph to render for %s", in.Issue)
+	}
+
+	if path, err := exec.LookPath("mmdc"); err == nil {
+		return r.renderWithMmdc(path, in, w)
+	}
+
+	return r.fetchFromMermaidInk(in, w)
+}
+
+// renderWithMmdc shells out to mmdc, which has no stdin/stdout streaming
+// mode: it renders via a headless browser screenshot and requires real
+// input/output file paths. We stage both as temp files and clean them up
+// once the output has been read back.
+func (r ImageRenderer) renderWithMmdc(mmdcPath string, in RenderInput, w io.Writer) error {
+	inFile, err := os.CreateTemp("", "jiradeps-*.mmd")
+	if err != nil {
+		return fmt.Errorf("creating mmdc input file: %w", err)
+	}
+	defer os.Remove(inFile.Name())
+
+	if _, err := inFile.WriteString(in.Flowchart.String()); err != nil {
+		inFile.Close()
+		return fmt.Errorf("writing mmdc input file: %w", err)
+	}
+	if err := inFile.Close(); err != nil {
+		return fmt.Errorf("writing mmdc input file: %w", err)
+	}
+
+	outFile, err := os.CreateTemp("", "jiradeps-*."+r.Format)
+	if err != nil {
+		return fmt.Errorf("creating mmdc output file: %w", err)
+	}
+	outFile.Close()
+	defer os.Remove(outFile.Name())
+
+	cmd := exec.Command(mmdcPath, "-i", inFile.Name(), "-o", outFile.Name(), "-e", r.Format)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("running mmdc: %w: %s", err, out)
+	}
+
+	data, err := os.ReadFile(outFile.Name())
+	if err != nil {
+		return fmt.Errorf("reading mmdc output file: %w", err)
+	}
+
+	_, err = w.Write(data)
+
+	return err
+}
+
+func (r ImageRenderer) fetchFromMermaidInk(in RenderInput, w io.Writer) error {
+	resp, err := http.Get(imgURL(in.Flowchart.LiveURL(), r.Format))
+	if err != nil {
+		return fmt.Errorf("fetching %s from mermaid.ink: %w", r.Format, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("fetching %s from mermaid.ink: unexpected status %s", r.Format, resp.Status)
+	}
+
+	_, err = io.Copy(w, resp.Body)
+
+	return err
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Heiko-san/mermaidgen/flowchart"
+)
+
+func TestDotRendererEmitsNodesAndEdges(t *testing.T) {
+	fc := flowchart.NewFlowchart()
+	a := fc.AddNode("ABC-1")
+	a.AddLines("ABC-1", "Do the thing")
+	b := fc.AddNode("ABC-2")
+	b.AddLines("ABC-2")
+	edge := fc.AddEdge(a, b)
+	edge.AddLines("blocks")
+
+	var buf bytes.Buffer
+	if err := (DotRenderer{}).Render(RenderInput{Flowchart: fc, Issue: "ABC-1"}, &buf); err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+
+	out := buf.String()
+	if !strings.Contains(out, `digraph "ABC-1"`) {
+		t.Errorf("output missing digraph header: %s", out)
+	}
+	if !strings.Contains(out, `"ABC-1" [label="ABC-1\\nDo the thing"]`) {
+		t.Errorf("output missing labeled node: %s", out)
+	}
+	if !strings.Contains(out, `"ABC-1" -> "ABC-2" [label="blocks"]`) {
+		t.Errorf("output missing edge: %s", out)
+	}
+}
+
+func TestJSONRendererEmitsNodesAndEdges(t *testing.T) {
+	graph := NewDepGraph()
+	graph.AddNode(GraphNode{Key: "ABC-1", Summary: "Do the thing"})
+	graph.AddEdge(GraphEdge{From: "ABC-1", To: "ABC-2", Type: "Blocks"})
+
+	var buf bytes.Buffer
+	if err := (JSONRenderer{}).Render(RenderInput{Graph: graph}, &buf); err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+
+	var got struct {
+		Nodes []GraphNode `json:"nodes"`
+		Edges []GraphEdge `json:"edges"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("unmarshalling output: %v", err)
+	}
+
+	if len(got.Nodes) != 1 || got.Nodes[0].Key != "ABC-1" {
+		t.Errorf("Nodes = %v, want one node keyed ABC-1", got.Nodes)
+	}
+	if len(got.Edges) != 1 || got.Edges[0].From != "ABC-1" || got.Edges[0].To != "ABC-2" {
+		t.Errorf("Edges = %v, want one ABC-1->ABC-2 edge", got.Edges)
+	}
+}
+
+// fakeMmdc writes a shell script standing in for mmdc: it copies the -i
+// input file to the -o output file, so renderWithMmdc's staging can be
+// verified without a real mermaid-cli install.
+func fakeMmdc(t *testing.T, exitCode int) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "mmdc")
+	script := "#!/bin/sh\n" +
+		"in=\"\"; out=\"\"\n" +
+		"while [ $# -gt 0 ]; do\n" +
+		"  case \"$1\" in\n" +
+		"    -i) in=\"$2\"; shift 2 ;;\n" +
+		"    -o) out=\"$2\"; shift 2 ;;\n" +
+		"    *) shift ;;\n" +
+		"  esac\n" +
+		"done\n"
+	if exitCode == 0 {
+		script += "cp \"$in\" \"$out\"\n"
+	} else {
+		script += "echo 'boom' >&2\n"
+		script += "exit 1\n"
+	}
+
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		t.Fatalf("writing fake mmdc: %v", err)
+	}
+
+	return path
+}
+
+func TestRenderWithMmdcStagesAndCleansUpTempFiles(t *testing.T) {
+	fc := flowchart.NewFlowchart()
+	a := fc.AddNode("ABC-1")
+	a.AddLines("ABC-1")
+	b := fc.AddNode("ABC-2")
+	b.AddLines("ABC-2")
+	fc.AddEdge(a, b)
+
+	before, err := filepath.Glob(filepath.Join(os.TempDir(), "jiradeps-*"))
+	if err != nil {
+		t.Fatalf("globbing temp dir: %v", err)
+	}
+
+	r := ImageRenderer{Format: "svg"}
+	var buf bytes.Buffer
+	if err := r.renderWithMmdc(fakeMmdc(t, 0), RenderInput{Flowchart: fc}, &buf); err != nil {
+		t.Fatalf("renderWithMmdc: %v", err)
+	}
+
+	if buf.String() != fc.String() {
+		t.Errorf("renderWithMmdc output = %q, want the staged mermaid source %q", buf.String(), fc.String())
+	}
+
+	after, err := filepath.Glob(filepath.Join(os.TempDir(), "jiradeps-*"))
+	if err != nil {
+		t.Fatalf("globbing temp dir: %v", err)
+	}
+	if len(after) != len(before) {
+		t.Errorf("jiradeps-* temp files left behind: before=%v after=%v", before, after)
+	}
+}
+
+func TestRenderWithMmdcPropagatesCommandError(t *testing.T) {
+	fc := flowchart.NewFlowchart()
+	fc.AddNode("ABC-1")
+
+	r := ImageRenderer{Format: "svg"}
+	var buf bytes.Buffer
+	err := r.renderWithMmdc(fakeMmdc(t, 1), RenderInput{Flowchart: fc}, &buf)
+	if err == nil {
+		t.Fatal("renderWithMmdc with a failing mmdc = nil error, want an error")
+	}
+	if !strings.Contains(err.Error(), "boom") {
+		t.Errorf("error = %v, want it to include the command's stderr", err)
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"sync"
+
+	"github.com/Heiko-san/mermaidgen/flowchart"
+	"github.com/andygrunwald/go-jira"
+	"golang.org/x/sync/errgroup"
+)
+
+// DefaultConcurrency is the number of issues whose links traverseLinks
+// expands in parallel when -concurrency isn't set. It does not apply to
+// fetching: each frontier level is fetched in a single bulk request.
+const DefaultConcurrency = 8
+
+// traverseLinks walks the dependency graph rooted at seeds breadth-first,
+// bulk-fetching each frontier level in one request and expanding up to
+// concurrency issues' links in parallel. It replaces the old recursive,
+// serial getAllLinks so that large epics with dozens of children fetch in a
+// handful of round trips instead of one per issue.
+func traverseLinks(client *jira.Client, seeds []*jira.Issue, fc *flowchart.Flowchart, graph *DepGraph, cache Cache, offline bool, concurrency int) error {
+	var mu sync.Mutex
+
+	links := StringSet{}
+	seen := StringSet{}
+
+	frontier := make([]*jira.Issue, 0, len(seeds))
+	for _, seed := range seeds {
+		seen.Add(seed.Key)
+		frontier = append(frontier, seed)
+	}
+
+	for len(frontier) > 0 {
+		var needFetch []string
+		for _, issue := range frontier {
+			if len(issue.Fields.IssueLinks) == 0 {
+				needFetch = append(needFetch, issue.Key)
+			}
+		}
+
+		fetched, err := BulkFetchIssues(context.Background(), client, needFetch, cache, offline)
+		if err != nil {
+			return err
+		}
+
+		byKey := make(map[string]*jira.Issue, len(fetched))
+		for i := range fetched {
+			byKey[fetched[i].Key] = &fetched[i]
+		}
+
+		nextByIssue := make([][]*jira.Issue, len(frontier))
+
+		g, _ := errgroup.WithContext(context.Background())
+		g.SetLimit(concurrency)
+
+		for idx, issue := range frontier {
+			idx, issue := idx, issue
+			if full, ok := byKey[issue.Key]; ok {
+				issue = full
+			}
+
+			g.Go(func() error {
+				nextByIssue[idx] = expandIssueLinks(issue, fc, graph, links, seen, &mu)
+				return nil
+			})
+		}
+
+		if err := g.Wait(); err != nil {
+			return err
+		}
+
+		frontier = frontier[:0]
+		for _, next := range nextByIssue {
+			frontier = append(frontier, next...)
+		}
+	}
+
+	return nil
+}
+
+// expandIssueLinks records every not-yet-seen link of issue into fc/graph
+// and returns the linked issues that still need to be visited. It's safe to
+// call concurrently for different issues; mu guards the shared links/seen
+// sets and the flowchart itself.
+func expandIssueLinks(issue *jira.Issue, fc *flowchart.Flowchart, graph *DepGraph, links, seen StringSet, mu *sync.Mutex) (next []*jira.Issue) {
+	for _, link := range issue.Fields.IssueLinks {
+		if link.OutwardIssue != nil {
+			if enqueued := addLinkOnce(fc, graph, links, seen, mu, JiraLink{From: issue, Link: link, To: link.OutwardIssue}, link.OutwardIssue); enqueued {
+				next = append(next, link.OutwardIssue)
+			}
+		}
+
+		if link.InwardIssue != nil {
+			if enqueued := addLinkOnce(fc, graph, links, seen, mu, JiraLink{From: link.InwardIssue, Link: link, To: issue}, link.InwardIssue); enqueued {
+				next = append(next, link.InwardIssue)
+			}
+		}
+	}
+
+	return next
+}
+
+// addLinkOnce adds jl to fc/graph if it hasn't been seen before, and reports
+// whether other should be added to the next BFS frontier (i.e. this is the
+// first time other's key has been encountered).
+func addLinkOnce(fc *flowchart.Flowchart, graph *DepGraph, links, seen StringSet, mu *sync.Mutex, jl JiraLink, other *jira.Issue) bool {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if links.Exists(jl.String()) {
+		return false
+	}
+	links.Add(jl.String())
+
+	AddLink(fc, graph, jl)
+
+	if seen.Exists(other.Key) {
+		return false
+	}
+	seen.Add(other.Key)
+
+	return true
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/Heiko-san/mermaidgen/flowchart"
+	"github.com/andygrunwald/go-jira"
+)
+
+func newTestIssue(key string) *jira.Issue {
+	return &jira.Issue{
+		Key: key,
+		Fields: &jira.IssueFields{
+			Status:   &jira.Status{Name: "To Do"},
+			Type:     jira.IssueType{Name: "Story"},
+			Priority: &jira.Priority{Name: "Medium"},
+		},
+	}
+}
+
+func TestExpandIssueLinksDedupesAcrossDirections(t *testing.T) {
+	a := newTestIssue("ABC-1")
+	b := newTestIssue("ABC-2")
+
+	link := &jira.IssueLink{
+		Type:         jira.IssueLinkType{Name: "Blocks", Inward: "is blocked by", Outward: "blocks"},
+		OutwardIssue: b,
+	}
+	a.Fields.IssueLinks = []*jira.IssueLink{link}
+
+	backLink := &jira.IssueLink{
+		Type:        jira.IssueLinkType{Name: "Blocks", Inward: "is blocked by", Outward: "blocks"},
+		InwardIssue: a,
+	}
+	b.Fields.IssueLinks = []*jira.IssueLink{backLink}
+
+	fc := flowchart.NewFlowchart()
+	graph := NewDepGraph()
+	links := StringSet{}
+	seen := StringSet{"ABC-1": {}}
+	var mu sync.Mutex
+
+	next := expandIssueLinks(a, fc, graph, links, seen, &mu)
+	if len(next) != 1 || next[0].Key != "ABC-2" {
+		t.Fatalf("expandIssueLinks(a) next = %v, want [ABC-2]", next)
+	}
+
+	// Expanding b afterwards re-traverses the same link from the other
+	// side; addLinkOnce must recognize it as already recorded and must
+	// not re-enqueue ABC-1, which is already seen.
+	next = expandIssueLinks(b, fc, graph, links, seen, &mu)
+	if len(next) != 0 {
+		t.Fatalf("expandIssueLinks(b) next = %v, want none (link already recorded, ABC-1 already seen)", next)
+	}
+
+	if len(graph.Edges) != 1 {
+		t.Errorf("graph.Edges = %d, want 1 (the reverse traversal must not duplicate it)", len(graph.Edges))
+	}
+}
+
+func TestAddLinkOnceSkipsAlreadySeenTarget(t *testing.T) {
+	a := newTestIssue("ABC-1")
+	b := newTestIssue("ABC-2")
+	link := &jira.IssueLink{Type: jira.IssueLinkType{Name: "Blocks", Outward: "blocks"}}
+
+	fc := flowchart.NewFlowchart()
+	graph := NewDepGraph()
+	links := StringSet{}
+	seen := StringSet{"ABC-2": {}} // already visited
+	var mu sync.Mutex
+
+	enqueued := addLinkOnce(fc, graph, links, seen, &mu, JiraLink{From: a, Link: link, To: b}, b)
+	if enqueued {
+		t.Error("addLinkOnce reported enqueued for a target already in seen")
+	}
+	if len(graph.Edges) != 1 {
+		t.Errorf("graph.Edges = %d, want 1 (the edge itself is still recorded)", len(graph.Edges))
+	}
+}